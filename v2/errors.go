@@ -0,0 +1,131 @@
+package toolkit
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors returned by Tools methods. Callers should use errors.Is against these
+// rather than matching on message text, which may carry request-specific detail.
+var (
+	ErrFileTooLarge        = errors.New("uploaded file is too big")
+	ErrDisallowedType      = errors.New("uploaded file type is not permitted")
+	ErrBadJSON             = errors.New("body contains badly formed JSON")
+	ErrWrongJSONType       = errors.New("body contains incorrect JSON type")
+	ErrUnknownField        = errors.New("body contains unknown key")
+	ErrEmptyBody           = errors.New("body must not be empty")
+	ErrMultipleJSONValues  = errors.New("body must contain only one JSON value")
+	ErrBodyTooLarge        = errors.New("body exceeds the configured size limit")
+	ErrRemoteRequestFailed = errors.New("remote request failed")
+)
+
+// ToolkitError wraps Cause with an HTTP Status and a machine-readable Code, so that
+// callers can errors.Is/errors.As their way to a specific failure instead of matching on
+// message text, while RespondError can still recover a status to send.
+type ToolkitError struct {
+	Status int
+	Code   string
+	Cause  error
+}
+
+// Error implements the error interface, returning Cause's message.
+func (e *ToolkitError) Error() string {
+	if e.Cause == nil {
+		return e.Code
+	}
+
+	return e.Cause.Error()
+}
+
+// Unwrap lets errors.Is/errors.As see through to Cause (and, transitively, to whatever
+// sentinel Cause itself wraps).
+func (e *ToolkitError) Unwrap() error { return e.Cause }
+
+// HTTPStatus implements the statusCoder interface RespondError looks for.
+func (e *ToolkitError) HTTPStatus() int { return e.Status }
+
+// sentinelError pairs a fixed sentinel with a request-specific message, so ReadJSON can
+// keep returning its existing message text while still supporting errors.Is(err, ErrX).
+type sentinelError struct {
+	msg      string
+	sentinel error
+}
+
+func (e *sentinelError) Error() string { return e.msg }
+func (e *sentinelError) Unwrap() error { return e.sentinel }
+
+func wrapSentinel(sentinel error, format string, args ...any) error {
+	return &sentinelError{msg: fmt.Sprintf(format, args...), sentinel: sentinel}
+}
+
+// jsonError builds the *ToolkitError ReadJSON returns for a given failure, preserving
+// its historical message text while attaching a status, machine code, and sentinel.
+func jsonError(status int, code string, sentinel error, format string, args ...any) error {
+	return &ToolkitError{Status: status, Code: code, Cause: wrapSentinel(sentinel, format, args...)}
+}
+
+// Response format constants for RespondError, named after linx-server's oopsHandler
+// modes. RespAUTO negotiates a format from the request's Accept header.
+const (
+	RespAUTO  = "auto"
+	RespJSON  = "json"
+	RespPLAIN = "plain"
+	RespHTML  = "html"
+)
+
+// statusCoder is satisfied by errors (such as *ToolkitError) that know their own HTTP
+// status code.
+type statusCoder interface {
+	HTTPStatus() int
+}
+
+// RespondError writes err to w, negotiating between JSON, plain text, and HTML based on
+// r's Accept header, or using format if one other than RespAUTO is passed. The status
+// code is taken from err when it satisfies statusCoder, defaulting to
+// http.StatusBadRequest otherwise.
+func (t *Tools) RespondError(w http.ResponseWriter, r *http.Request, err error, format ...string) {
+	resFormat := RespAUTO
+	if len(format) > 0 {
+		resFormat = format[0]
+	}
+
+	if resFormat == RespAUTO {
+		resFormat = negotiateFormat(r.Header.Get("Accept"))
+	}
+
+	status := http.StatusBadRequest
+	var sc statusCoder
+	if errors.As(err, &sc) {
+		status = sc.HTTPStatus()
+	}
+
+	switch resFormat {
+	case RespHTML:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "<html><body><h1>Error</h1><p>%s</p></body></html>", html.EscapeString(err.Error()))
+	case RespPLAIN:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		fmt.Fprintln(w, err.Error())
+	default:
+		_ = t.WriteJSON(w, status, JSONResponse{Error: true, Message: err.Error()})
+	}
+}
+
+// negotiateFormat picks a RespondError format from an Accept header: HTML if text/html
+// appears anywhere in it, else plain text if text/plain does, else JSON. The order of
+// the listed types and their q-values are not consulted.
+func negotiateFormat(accept string) string {
+	switch {
+	case strings.Contains(accept, "text/html"):
+		return RespHTML
+	case strings.Contains(accept, "text/plain"):
+		return RespPLAIN
+	default:
+		return RespJSON
+	}
+}
@@ -0,0 +1,109 @@
+package toolkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"text/html", RespHTML},
+		{"text/html,application/xhtml+xml", RespHTML},
+		{"text/plain", RespPLAIN},
+		{"application/json", RespJSON},
+		{"", RespJSON},
+	}
+
+	for _, e := range tests {
+		if got := negotiateFormat(e.accept); got != e.want {
+			t.Errorf("negotiateFormat(%q) = %q, want %q", e.accept, got, e.want)
+		}
+	}
+}
+
+func TestTools_RespondError(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		err         error
+		wantStatus  int
+		wantType    string
+		wantContain string
+	}{
+		{
+			name:        "defaults to JSON",
+			accept:      "",
+			err:         ErrFileTooLarge,
+			wantStatus:  http.StatusBadRequest,
+			wantType:    "application/json",
+			wantContain: `"error":true`,
+		},
+		{
+			name:        "honors Accept: text/plain",
+			accept:      "text/plain",
+			err:         ErrFileTooLarge,
+			wantStatus:  http.StatusBadRequest,
+			wantType:    "text/plain",
+			wantContain: ErrFileTooLarge.Error(),
+		},
+		{
+			name:        "honors Accept: text/html and escapes the message",
+			accept:      "text/html",
+			err:         wrapSentinel(ErrBadJSON, "<script>alert(1)</script>"),
+			wantStatus:  http.StatusBadRequest,
+			wantType:    "text/html",
+			wantContain: "&lt;script&gt;",
+		},
+		{
+			name:        "takes status from a statusCoder error",
+			accept:      "",
+			err:         &ToolkitError{Status: http.StatusUnprocessableEntity, Cause: ErrDisallowedType},
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantType:    "application/json",
+			wantContain: ErrDisallowedType.Error(),
+		},
+	}
+
+	for _, e := range tests {
+		t.Run(e.name, func(t *testing.T) {
+			var tools Tools
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if e.accept != "" {
+				req.Header.Set("Accept", e.accept)
+			}
+
+			tools.RespondError(rr, req, e.err)
+
+			if rr.Code != e.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, e.wantStatus)
+			}
+			if ct := rr.Header().Get("Content-Type"); !strings.Contains(ct, e.wantType) {
+				t.Errorf("Content-Type = %q, want it to contain %q", ct, e.wantType)
+			}
+			if !strings.Contains(rr.Body.String(), e.wantContain) {
+				t.Errorf("body = %q, want it to contain %q", rr.Body.String(), e.wantContain)
+			}
+		})
+	}
+}
+
+func TestTools_RespondError_ExplicitFormatOverridesAccept(t *testing.T) {
+	var tools Tools
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/json")
+
+	tools.RespondError(rr, req, ErrFileTooLarge, RespPLAIN)
+
+	if ct := rr.Header().Get("Content-Type"); !strings.Contains(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want it to contain text/plain", ct)
+	}
+}
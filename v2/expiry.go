@@ -0,0 +1,339 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// NeverExpires is the zero value of UploadOptions.Expiry and marks an upload as never
+// expiring.
+const NeverExpires time.Duration = 0
+
+// metaSuffix is appended to an upload's key to form the key of its metadata sidecar.
+const metaSuffix = ".meta.json"
+
+// ErrExpiryTooLong is returned by UploadFilesWithOptions when the requested expiry
+// exceeds Tools.MaxExpiry.
+var ErrExpiryTooLong = errors.New("requested expiry exceeds the maximum allowed")
+
+// ErrInvalidDeleteKey is returned by DeleteUpload when deleteKey does not match the one
+// the upload was created with.
+var ErrInvalidDeleteKey = errors.New("invalid delete key")
+
+// UploadOptions configures an expiring upload created via UploadFilesWithOptions.
+type UploadOptions struct {
+	// Expiry is how long the upload should live before StartExpiryReaper removes it.
+	// NeverExpires (the zero value) means it is never removed automatically.
+	Expiry time.Duration
+
+	// DeleteKey, if set, is the plaintext key a caller must present to DeleteUpload to
+	// remove the file early. If empty, one is generated and returned on the resulting
+	// UploadedFile.
+	DeleteKey string
+
+	// Randomize controls whether the stored file name is randomized, equivalent to the
+	// rename flag on UploadFiles.
+	Randomize bool
+
+	// AccessKey, if set, is recorded in the upload's metadata for callers that gate
+	// downloads behind a shared secret. The toolkit itself does not enforce it.
+	AccessKey string
+}
+
+// UploadMetadata is the JSON sidecar written alongside every upload made via
+// UploadFilesWithOptions.
+type UploadMetadata struct {
+	OriginalFileName string    `json:"original_file_name"`
+	SHA256           string    `json:"sha256"`
+	Size             int64     `json:"size"`
+	MimeType         string    `json:"mime_type"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	DeleteKeyHash    string    `json:"delete_key_hash"`
+	AccessKey        string    `json:"access_key,omitempty"`
+}
+
+// UploadFilesWithOptions uploads one or more files the same way UploadFiles does, but
+// additionally records an expiry, a bcrypt-hashed delete key, and a metadata sidecar for
+// each file, so callers can build linx/transfer.sh-style ephemeral file services.
+func (t *Tools) UploadFilesWithOptions(r *http.Request, uploadDir string, opts UploadOptions) ([]*UploadedFile, error) {
+	if t.MaxFileSize == 0 {
+		t.MaxFileSize = 1024 * 1024 * 1024
+	}
+
+	if t.MaxExpiry > 0 && opts.Expiry > t.MaxExpiry {
+		return nil, ErrExpiryTooLong
+	}
+
+	backend := t.storageBackend(uploadDir)
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	var uploadedFiles []*UploadedFile
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return uploadedFiles, err
+		}
+
+		if part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		uploadedFile, err := t.uploadPartWithOptions(r.Context(), backend, part, opts)
+		part.Close()
+		if err != nil {
+			return uploadedFiles, err
+		}
+
+		uploadedFiles = append(uploadedFiles, uploadedFile)
+	}
+
+	return uploadedFiles, nil
+}
+
+func (t *Tools) uploadPartWithOptions(ctx context.Context, backend StorageBackend, part *multipart.Part, opts UploadOptions) (*UploadedFile, error) {
+	fileType, uploadedFile, stream, err := t.sniffPart(part, opts.Randomize)
+	if err != nil {
+		return nil, err
+	}
+
+	uploadedFile.NewFileName, err = t.uniqueKey(ctx, backend, uploadedFile.NewFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	limited := io.LimitReader(stream, int64(t.MaxFileSize)+1)
+
+	var uploaded *UploadedFile
+	if t.ImagePipeline != nil && supportsImageType(fileType.String()) {
+		uploaded, err = t.uploadImagePart(ctx, backend, uploadedFile, limited)
+	} else {
+		uploaded, err = t.uploadStreamPart(ctx, backend, uploadedFile, limited)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deleteKey := opts.DeleteKey
+	if deleteKey == "" {
+		deleteKey = t.RandomString(25)
+	}
+
+	deleteKeyHash, err := bcrypt.GenerateFromPassword([]byte(deleteKey), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt time.Time
+	if opts.Expiry != NeverExpires {
+		expiresAt = time.Now().Add(opts.Expiry)
+	}
+
+	uploaded.DeleteKey = deleteKey
+	uploaded.ExpiresAt = expiresAt
+
+	if err := t.writeUploadMetadata(ctx, backend, uploaded, fileType.String(), opts, deleteKeyHash, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return uploaded, nil
+}
+
+// uniqueKey returns key unchanged if nothing is stored under it yet, otherwise
+// disambiguates it by inserting a short random suffix before its extension, trying up to
+// 10 keys in all before giving up with an error. Unlike UploadFiles, UploadFilesWithOptions defaults
+// Randomize to false, so without this, two uploads that happen to share an original file
+// name would silently clobber each other's content and metadata sidecar.
+func (t *Tools) uniqueKey(ctx context.Context, backend StorageBackend, key string) (string, error) {
+	candidate := key
+
+	for i := 0; i < 10; i++ {
+		_, err := backend.Stat(ctx, candidate)
+		if errors.Is(err, ErrObjectNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		ext := filepath.Ext(key)
+		base := strings.TrimSuffix(key, ext)
+		candidate = fmt.Sprintf("%s-%s%s", base, t.RandomString(8), ext)
+	}
+
+	return "", errors.New("toolkit: could not find a unique key for upload")
+}
+
+// writeUploadMetadata re-reads the object just stored for uploaded (so the recorded
+// SHA256 matches what's actually on the backend, even after an ImagePipeline re-encode)
+// and writes the resulting UploadMetadata to its sidecar.
+func (t *Tools) writeUploadMetadata(ctx context.Context, backend StorageBackend, uploaded *UploadedFile, mimeType string, opts UploadOptions, deleteKeyHash []byte, expiresAt time.Time) error {
+	rc, _, err := backend.Get(ctx, uploaded.NewFileName)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, rc); err != nil {
+		return err
+	}
+
+	meta := UploadMetadata{
+		OriginalFileName: uploaded.OriginalFileName,
+		SHA256:           hex.EncodeToString(hasher.Sum(nil)),
+		Size:             uploaded.FileSize,
+		MimeType:         mimeType,
+		ExpiresAt:        expiresAt,
+		DeleteKeyHash:    string(deleteKeyHash),
+		AccessKey:        opts.AccessKey,
+	}
+
+	metaBytes, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	_, err = backend.Put(ctx, uploaded.NewFileName+metaSuffix, bytes.NewReader(metaBytes), nil)
+
+	return err
+}
+
+// GetUploadMetadata returns the metadata sidecar recorded for name by
+// UploadFilesWithOptions.
+func (t *Tools) GetUploadMetadata(name string) (*UploadMetadata, error) {
+	backend, err := t.requireBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	rc, _, err := backend.Get(context.Background(), name+metaSuffix)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var meta UploadMetadata
+	if err := json.NewDecoder(rc).Decode(&meta); err != nil {
+		return nil, err
+	}
+
+	return &meta, nil
+}
+
+// DeleteUpload removes the upload stored as name, provided deleteKey matches the one it
+// was created with.
+func (t *Tools) DeleteUpload(name, deleteKey string) error {
+	backend, err := t.requireBackend()
+	if err != nil {
+		return err
+	}
+
+	meta, err := t.GetUploadMetadata(name)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(meta.DeleteKeyHash), []byte(deleteKey)); err != nil {
+		return ErrInvalidDeleteKey
+	}
+
+	ctx := context.Background()
+
+	if err := backend.Delete(ctx, name); err != nil {
+		return err
+	}
+
+	return backend.Delete(ctx, name+metaSuffix)
+}
+
+// StartExpiryReaper starts a goroutine that periodically scans Backend for uploads whose
+// metadata sidecar records an expiry in the past, and removes them. It stops when ctx is
+// canceled.
+func (t *Tools) StartExpiryReaper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.reapExpiredUploads(ctx)
+			}
+		}
+	}()
+}
+
+func (t *Tools) reapExpiredUploads(ctx context.Context) {
+	backend, err := t.requireBackend()
+	if err != nil {
+		return
+	}
+
+	keys, err := backend.List(ctx)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+
+	for _, key := range keys {
+		if !strings.HasSuffix(key, metaSuffix) {
+			continue
+		}
+
+		rc, _, err := backend.Get(ctx, key)
+		if err != nil {
+			continue
+		}
+
+		var meta UploadMetadata
+		decodeErr := json.NewDecoder(rc).Decode(&meta)
+		rc.Close()
+		if decodeErr != nil {
+			continue
+		}
+
+		if meta.ExpiresAt.IsZero() || meta.ExpiresAt.After(now) {
+			continue
+		}
+
+		name := strings.TrimSuffix(key, metaSuffix)
+		_ = backend.Delete(ctx, name)
+		_ = backend.Delete(ctx, key)
+	}
+}
+
+// requireBackend returns t.Backend, or an error if it has not been configured. Unlike
+// UploadFiles, the expiry subsystem has no per-call uploadDir to fall back to, so a
+// Backend must be set explicitly.
+func (t *Tools) requireBackend() (StorageBackend, error) {
+	if t.Backend == nil {
+		return nil, errors.New("toolkit: no storage backend configured")
+	}
+
+	return t.Backend, nil
+}
@@ -0,0 +1,156 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func uploadOneWithOptions(t *testing.T, tools *Tools, opts UploadOptions) *UploadedFile {
+	t.Helper()
+
+	content := pngBytes(t)
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	go func() {
+		defer writer.Close()
+		defer wg.Done()
+
+		part, err := writer.CreateFormFile("file", "upload.png")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if _, err := part.Write(content); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	request := httptest.NewRequest("POST", "/", pr)
+	request.Header.Add("Content-Type", writer.FormDataContentType())
+
+	files, err := tools.UploadFilesWithOptions(request, "uploads", opts)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("UploadFilesWithOptions: %s", err.Error())
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected 1 uploaded file, got %d", len(files))
+	}
+
+	return files[0]
+}
+
+func TestTools_UploadFilesWithOptions_WritesMetadata(t *testing.T) {
+	tools := &Tools{Backend: &MemoryBackend{}}
+
+	uploaded := uploadOneWithOptions(t, tools, UploadOptions{Expiry: time.Hour, AccessKey: "secret"})
+
+	meta, err := tools.GetUploadMetadata(uploaded.NewFileName)
+	if err != nil {
+		t.Fatalf("GetUploadMetadata: %s", err.Error())
+	}
+
+	if meta.OriginalFileName != "upload.png" {
+		t.Errorf("wrong original file name, got %s", meta.OriginalFileName)
+	}
+	if meta.AccessKey != "secret" {
+		t.Errorf("wrong access key, got %s", meta.AccessKey)
+	}
+	if meta.SHA256 == "" {
+		t.Error("expected SHA256 to be recorded")
+	}
+	if meta.ExpiresAt.IsZero() {
+		t.Error("expected ExpiresAt to be set")
+	}
+}
+
+func TestTools_UploadFilesWithOptions_RespectsAllowedFileTypes(t *testing.T) {
+	tools := &Tools{Backend: &MemoryBackend{}, AllowedFileTypes: []string{"image/jpeg"}}
+
+	content := pngBytes(t)
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		defer writer.Close()
+		part, _ := writer.CreateFormFile("file", "upload.png")
+		_, _ = part.Write(content)
+	}()
+
+	request := httptest.NewRequest("POST", "/", pr)
+	request.Header.Add("Content-Type", writer.FormDataContentType())
+
+	if _, err := tools.UploadFilesWithOptions(request, "uploads", UploadOptions{}); !errors.Is(err, ErrDisallowedType) {
+		t.Errorf("expected ErrDisallowedType, got %v", err)
+	}
+}
+
+func TestTools_UploadFilesWithOptions_ExceedsMaxExpiry(t *testing.T) {
+	tools := &Tools{Backend: &MemoryBackend{}, MaxExpiry: time.Minute}
+
+	content := pngBytes(t)
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		defer writer.Close()
+		part, _ := writer.CreateFormFile("file", "upload.png")
+		_, _ = part.Write(content)
+	}()
+
+	request := httptest.NewRequest("POST", "/", pr)
+	request.Header.Add("Content-Type", writer.FormDataContentType())
+
+	if _, err := tools.UploadFilesWithOptions(request, "uploads", UploadOptions{Expiry: time.Hour}); !errors.Is(err, ErrExpiryTooLong) {
+		t.Errorf("expected ErrExpiryTooLong, got %v", err)
+	}
+}
+
+func TestTools_DeleteUpload(t *testing.T) {
+	tools := &Tools{Backend: &MemoryBackend{}}
+
+	uploaded := uploadOneWithOptions(t, tools, UploadOptions{DeleteKey: "my-secret-key"})
+
+	if err := tools.DeleteUpload(uploaded.NewFileName, "wrong-key"); !errors.Is(err, ErrInvalidDeleteKey) {
+		t.Errorf("expected ErrInvalidDeleteKey, got %v", err)
+	}
+
+	if err := tools.DeleteUpload(uploaded.NewFileName, "my-secret-key"); err != nil {
+		t.Fatalf("DeleteUpload: %s", err.Error())
+	}
+
+	if _, err := tools.Backend.Stat(context.Background(), uploaded.NewFileName); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("expected upload to be removed, got %v", err)
+	}
+	if _, err := tools.GetUploadMetadata(uploaded.NewFileName); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("expected metadata sidecar to be removed, got %v", err)
+	}
+}
+
+func TestTools_reapExpiredUploads(t *testing.T) {
+	tools := &Tools{Backend: &MemoryBackend{}}
+
+	expired := uploadOneWithOptions(t, tools, UploadOptions{Expiry: time.Nanosecond})
+	keep := uploadOneWithOptions(t, tools, UploadOptions{Expiry: time.Hour})
+
+	time.Sleep(time.Millisecond)
+	tools.reapExpiredUploads(context.Background())
+
+	if _, err := tools.Backend.Stat(context.Background(), expired.NewFileName); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("expected expired upload to be reaped, got %v", err)
+	}
+	if _, err := tools.Backend.Stat(context.Background(), keep.NewFileName); err != nil {
+		t.Errorf("expected unexpired upload to survive, got %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package toolkit
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// defaultMaxDecodedPixels is used when ImagePipeline.MaxDecodedPixels is unset.
+const defaultMaxDecodedPixels = 64_000_000 // 64 megapixels
+
+// supportsImageType reports whether mimeType is one ImagePipeline.run can actually
+// decode. Callers should route only these through the pipeline and store anything else
+// (e.g. image/gif, image/webp) unprocessed, even if AllowedFileTypes permits it.
+func supportsImageType(mimeType string) bool {
+	return mimeType == "image/jpeg" || mimeType == "image/png"
+}
+
+// ErrImageTooLarge is returned when an image's declared dimensions would exceed the
+// configured decoded-pixel budget. It is checked before the image is fully decoded, so a
+// crafted image with a tiny file size but an enormous declared resolution (a
+// "decompression bomb") cannot exhaust memory.
+var ErrImageTooLarge = errors.New("image dimensions exceed the configured memory cap")
+
+// ImageStage is a single step in an ImagePipeline.
+type ImageStage interface {
+	apply(s *imageState) error
+}
+
+type imageStageFunc func(s *imageState) error
+
+func (f imageStageFunc) apply(s *imageState) error { return f(s) }
+
+// imageState is threaded through an ImagePipeline's stages.
+type imageState struct {
+	img      image.Image
+	format   string // "jpeg" or "png"
+	quality  int
+	variants map[string]image.Image
+}
+
+// ImagePipeline resizes, thumbnails, and re-encodes images as they are uploaded. Set it
+// on Tools.ImagePipeline to run it over every uploaded file whose sniffed MIME type is
+// image/jpeg or image/png; other image types are stored unprocessed.
+type ImagePipeline struct {
+	Stages []ImageStage
+
+	// MaxDecodedPixels caps width*height of a decoded image. Zero means
+	// defaultMaxDecodedPixels.
+	MaxDecodedPixels int64
+}
+
+// Resize scales the image down to fit within maxW x maxH, preserving aspect ratio. It is
+// a no-op if the image already fits.
+func Resize(maxW, maxH int) ImageStage {
+	return imageStageFunc(func(s *imageState) error {
+		b := s.img.Bounds()
+		w, h := b.Dx(), b.Dy()
+
+		if w <= maxW && h <= maxH {
+			return nil
+		}
+
+		nw, nh := scaledDimensions(w, h, maxW, maxH)
+		dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), s.img, b, draw.Over, nil)
+		s.img = dst
+
+		return nil
+	})
+}
+
+// Thumbnail generates a w x h variant of the image, stored separately under suffix
+// rather than replacing the main image.
+func Thumbnail(w, h int, suffix string) ImageStage {
+	return imageStageFunc(func(s *imageState) error {
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), s.img, s.img.Bounds(), draw.Over, nil)
+
+		if s.variants == nil {
+			s.variants = make(map[string]image.Image)
+		}
+		s.variants[suffix] = dst
+
+		return nil
+	})
+}
+
+// StripEXIF is a documentation-only stage: Go's standard image/jpeg and image/png
+// decoders never retain EXIF data in the first place, so by the time a stage runs there
+// is nothing left to strip. It exists so a pipeline can say so explicitly rather than
+// leaving EXIF handling implicit.
+func StripEXIF() ImageStage {
+	return imageStageFunc(func(*imageState) error {
+		return nil
+	})
+}
+
+// ReencodeJPEG re-encodes the main image (and any variants) as JPEG at the given quality
+// (1-100), regardless of the source format.
+func ReencodeJPEG(quality int) ImageStage {
+	return imageStageFunc(func(s *imageState) error {
+		s.format = "jpeg"
+		s.quality = quality
+
+		return nil
+	})
+}
+
+func scaledDimensions(w, h, maxW, maxH int) (int, int) {
+	if w == 0 || h == 0 {
+		return maxW, maxH
+	}
+
+	ratio := float64(w) / float64(h)
+	nw, nh := maxW, int(float64(maxW)/ratio)
+
+	if nh > maxH {
+		nh = maxH
+		nw = int(float64(maxH) * ratio)
+	}
+
+	if nw < 1 {
+		nw = 1
+	}
+	if nh < 1 {
+		nh = 1
+	}
+
+	return nw, nh
+}
+
+// encodedImage is an image that has been run through an ImagePipeline and encoded back
+// to bytes.
+type encodedImage struct {
+	data []byte
+	ext  string
+}
+
+// run decodes data, guards against decompression bombs, executes every stage in order,
+// and re-encodes the main image plus any variants the stages produced.
+func (p *ImagePipeline) run(data []byte) (main encodedImage, variants map[string]encodedImage, err error) {
+	maxPixels := p.MaxDecodedPixels
+	if maxPixels == 0 {
+		maxPixels = defaultMaxDecodedPixels
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return encodedImage{}, nil, err
+	}
+
+	if int64(cfg.Width)*int64(cfg.Height) > maxPixels {
+		return encodedImage{}, nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return encodedImage{}, nil, err
+	}
+
+	state := &imageState{img: img, format: format, quality: 90}
+
+	for _, stage := range p.Stages {
+		if err := stage.apply(state); err != nil {
+			return encodedImage{}, nil, err
+		}
+	}
+
+	mainData, mainExt, err := encodeImage(state.img, state.format, state.quality)
+	if err != nil {
+		return encodedImage{}, nil, err
+	}
+
+	var encodedVariants map[string]encodedImage
+	if len(state.variants) > 0 {
+		encodedVariants = make(map[string]encodedImage, len(state.variants))
+
+		for name, vimg := range state.variants {
+			vdata, vext, err := encodeImage(vimg, state.format, state.quality)
+			if err != nil {
+				return encodedImage{}, nil, err
+			}
+			encodedVariants[name] = encodedImage{data: vdata, ext: vext}
+		}
+	}
+
+	return encodedImage{data: mainData, ext: mainExt}, encodedVariants, nil
+}
+
+func encodeImage(img image.Image, format string, quality int) ([]byte, string, error) {
+	var buf bytes.Buffer
+
+	if format == "png" {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), ".png", nil
+	}
+
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), ".jpg", nil
+}
@@ -0,0 +1,174 @@
+package toolkit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// minimalGIF is a well-known 1x1 transparent GIF89a, used because mimetype sniffs GIFs
+// from their magic bytes alone, so a hand-built image package doesn't need to produce one.
+var minimalGIF = func() []byte {
+	data, err := base64.StdEncoding.DecodeString("R0lGODlhAQABAIAAAAAAAP///ywAAAAAAQABAAACAUwAOw==")
+	if err != nil {
+		panic(err)
+	}
+	return data
+}()
+
+func solidPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{G: 255, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encoding test PNG: %s", err.Error())
+	}
+
+	return buf.Bytes()
+}
+
+func TestSupportsImageType(t *testing.T) {
+	tests := []struct {
+		mimeType string
+		want     bool
+	}{
+		{"image/jpeg", true},
+		{"image/png", true},
+		{"image/gif", false},
+		{"image/webp", false},
+		{"text/plain", false},
+	}
+
+	for _, e := range tests {
+		if got := supportsImageType(e.mimeType); got != e.want {
+			t.Errorf("supportsImageType(%q) = %v, want %v", e.mimeType, got, e.want)
+		}
+	}
+}
+
+func TestImagePipeline_Run_ResizeAndReencodeJPEG(t *testing.T) {
+	p := &ImagePipeline{Stages: []ImageStage{Resize(4, 4), ReencodeJPEG(80)}}
+
+	main, variants, err := p.run(solidPNG(t, 16, 16))
+	if err != nil {
+		t.Fatalf("run: %s", err.Error())
+	}
+	if len(variants) != 0 {
+		t.Errorf("expected no variants, got %d", len(variants))
+	}
+	if main.ext != ".jpg" {
+		t.Errorf("expected .jpg extension, got %s", main.ext)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(main.data))
+	if err != nil {
+		t.Fatalf("decoding resized image: %s", err.Error())
+	}
+
+	b := img.Bounds()
+	if b.Dx() > 4 || b.Dy() > 4 {
+		t.Errorf("expected image to fit within 4x4, got %dx%d", b.Dx(), b.Dy())
+	}
+}
+
+func TestImagePipeline_Run_Thumbnail(t *testing.T) {
+	p := &ImagePipeline{Stages: []ImageStage{Thumbnail(2, 2, "thumb")}}
+
+	_, variants, err := p.run(solidPNG(t, 16, 16))
+	if err != nil {
+		t.Fatalf("run: %s", err.Error())
+	}
+
+	v, ok := variants["thumb"]
+	if !ok {
+		t.Fatal("expected a \"thumb\" variant")
+	}
+
+	img, err := png.Decode(bytes.NewReader(v.data))
+	if err != nil {
+		t.Fatalf("decoding thumbnail: %s", err.Error())
+	}
+
+	b := img.Bounds()
+	if b.Dx() != 2 || b.Dy() != 2 {
+		t.Errorf("expected a 2x2 thumbnail, got %dx%d", b.Dx(), b.Dy())
+	}
+}
+
+func TestImagePipeline_Run_RejectsDecompressionBomb(t *testing.T) {
+	p := &ImagePipeline{MaxDecodedPixels: 10}
+
+	if _, _, err := p.run(solidPNG(t, 16, 16)); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("expected ErrImageTooLarge, got %v", err)
+	}
+}
+
+func TestTools_UploadFiles_SkipsPipelineForUnsupportedImageTypes(t *testing.T) {
+	tools := &Tools{
+		Backend:       &MemoryBackend{},
+		ImagePipeline: &ImagePipeline{Stages: []ImageStage{Resize(4, 4)}},
+	}
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	go func() {
+		defer writer.Close()
+		defer wg.Done()
+
+		part, err := writer.CreateFormFile("file", "animated.gif")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if _, err := part.Write(minimalGIF); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	request := httptest.NewRequest("POST", "/", pr)
+	request.Header.Add("Content-Type", writer.FormDataContentType())
+
+	uploaded, err := tools.UploadFiles(request, "uploads", false)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("expected an unsupported image type to upload unprocessed, got error: %s", err.Error())
+	}
+
+	if _, err := tools.Backend.Stat(request.Context(), uploaded[0].NewFileName); err != nil {
+		t.Fatalf("expected the GIF to be stored: %s", err.Error())
+	}
+
+	read, _, err := tools.Backend.Get(request.Context(), uploaded[0].NewFileName)
+	if err != nil {
+		t.Fatalf("Get: %s", err.Error())
+	}
+	defer read.Close()
+
+	stored, err := io.ReadAll(read)
+	if err != nil {
+		t.Fatalf("reading stored content: %s", err.Error())
+	}
+
+	if !bytes.Equal(stored, minimalGIF) {
+		t.Error("expected the GIF to be stored byte-for-byte unprocessed")
+	}
+}
@@ -0,0 +1,268 @@
+package toolkit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// BasicAuth holds credentials for HTTP basic authentication.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// RetryPolicy configures how PushJSONToRemote retries a failed request.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first. Zero (or one)
+	// means no retries.
+	MaxAttempts int
+
+	// BaseDelay is the backoff before the first retry; it doubles on each subsequent
+	// retry, capped at MaxDelay. The delay actually waited is drawn at random between
+	// half that backoff and the full backoff. Zero means 200ms.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the computed backoff delay. Zero means 5s.
+	MaxDelay time.Duration
+}
+
+// RemoteRequestOptions configures PushJSONToRemote and FetchJSON.
+type RemoteRequestOptions struct {
+	Client      *http.Client
+	Headers     http.Header
+	BearerToken string
+	BasicAuth   *BasicAuth
+	Retry       RetryPolicy
+}
+
+// PushJSONToRemote posts data to uri as JSON, retrying on network errors and 5xx/429
+// responses per opts.Retry, and returns the final response, its status code, and
+// possibly an error. The request body is streamed via an io.Pipe rather than marshaled
+// up front, so large payloads are not buffered in memory.
+//
+// If every attempt fails, it returns a nil response, a zero status, and the last error:
+// either the network error, or a *ToolkitError wrapping ErrRemoteRequestFailed that
+// carries the last retryable status.
+//
+// Unlike ErrorJSON and friends, the caller is responsible for closing the returned
+// response's Body; it is deliberately not closed here.
+func (t *Tools) PushJSONToRemote(ctx context.Context, uri string, data any, opts ...RemoteRequestOptions) (*http.Response, int, error) {
+	var o RemoteRequestOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	client := o.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	maxAttempts := o.Retry.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 1
+	}
+
+	var lastErr error
+	var retryAfter time.Duration
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			delay := retryAfter
+			if delay == 0 {
+				delay = backoffDelay(o.Retry, attempt-1)
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil, 0, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		res, status, ra, err := t.pushJSONOnce(ctx, client, uri, data, o)
+		retryAfter = ra
+
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if !shouldRetryStatus(status) {
+			return res, status, nil
+		}
+
+		_, _ = io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		lastErr = remoteStatusError(status)
+	}
+
+	return nil, 0, lastErr
+}
+
+func (t *Tools) pushJSONOnce(ctx context.Context, client *http.Client, uri string, data any, o RemoteRequestOptions) (*http.Response, int, time.Duration, error) {
+	pr, pw := io.Pipe()
+
+	go func() {
+		pw.CloseWithError(json.NewEncoder(pw).Encode(data))
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, pr)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	applyRemoteRequestOptions(req, o)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var retryAfter time.Duration
+	if shouldRetryStatus(res.StatusCode) {
+		retryAfter = parseRetryAfter(res.Header.Get("Retry-After"))
+	}
+
+	return res, res.StatusCode, retryAfter, nil
+}
+
+// FetchJSON gets url and decodes its JSON response body into out, applying the same
+// validation ReadJSON applies to request bodies: a size cap via MaxJSONSize, unknown
+// fields disallowed unless AllowUnknownFields is set, and a check that the body
+// contains exactly one JSON value.
+func (t *Tools) FetchJSON(ctx context.Context, url string, out any, opts ...RemoteRequestOptions) error {
+	var o RemoteRequestOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	client := o.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	applyRemoteRequestOptions(req, o)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return remoteStatusError(res.StatusCode)
+	}
+
+	maxBytes := 1024 * 1024
+	if t.MaxJSONSize != 0 {
+		maxBytes = t.MaxJSONSize
+	}
+
+	// body.N reaches zero only once more than maxBytes bytes have been read.
+	body := &io.LimitedReader{R: res.Body, N: int64(maxBytes) + 1}
+	dec := json.NewDecoder(body)
+	if !t.AllowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(out); err != nil {
+		if body.N == 0 {
+			return jsonError(http.StatusRequestEntityTooLarge, "body_too_large", ErrBodyTooLarge, "remote response must not be larger than %d bytes", maxBytes)
+		}
+		return jsonError(http.StatusBadRequest, "bad_json", ErrBadJSON, "remote response contains badly formed JSON: %s", err.Error())
+	}
+
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return jsonError(http.StatusBadRequest, "multiple_json_values", ErrMultipleJSONValues, "remote response must contain only one JSON value")
+	}
+
+	if body.N == 0 {
+		return jsonError(http.StatusRequestEntityTooLarge, "body_too_large", ErrBodyTooLarge, "remote response must not be larger than %d bytes", maxBytes)
+	}
+
+	return nil
+}
+
+func applyRemoteRequestOptions(req *http.Request, o RemoteRequestOptions) {
+	for k, vv := range o.Headers {
+		for _, v := range vv {
+			req.Header.Add(k, v)
+		}
+	}
+
+	if o.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+o.BearerToken)
+	}
+
+	if o.BasicAuth != nil {
+		req.SetBasicAuth(o.BasicAuth.Username, o.BasicAuth.Password)
+	}
+}
+
+// remoteStatusError wraps a non-2xx status from a remote request as a *ToolkitError
+// carrying that same status, so callers can errors.Is(err, ErrRemoteRequestFailed) or
+// recover the status via RespondError the same way ReadJSON's errors work.
+func remoteStatusError(status int) error {
+	return &ToolkitError{
+		Status: status,
+		Code:   "remote_request_failed",
+		Cause:  wrapSentinel(ErrRemoteRequestFailed, "remote returned status %d", status),
+	}
+}
+
+func shouldRetryStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// backoffDelay computes an exponential backoff delay with jitter for the nth failed
+// attempt (1-indexed).
+func backoffDelay(p RetryPolicy, failedAttempts int) time.Duration {
+	base := p.BaseDelay
+	if base == 0 {
+		base = 200 * time.Millisecond
+	}
+
+	maxDelay := p.MaxDelay
+	if maxDelay == 0 {
+		maxDelay = 5 * time.Second
+	}
+
+	d := base * time.Duration(int64(1)<<uint(failedAttempts-1))
+	if d <= 0 || d > maxDelay {
+		d = maxDelay
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(d) + 1))
+
+	return d/2 + jitter/2
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of seconds or an
+// HTTP date. It returns 0 if v is empty or unparseable.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+
+	if when, err := http.ParseTime(v); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,271 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTools_PushJSONToRemote_RetriesOn5xxThenSucceeds(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var tools Tools
+
+	res, status, err := tools.PushJSONToRemote(context.Background(), server.URL, map[string]string{"a": "b"}, RemoteRequestOptions{
+		Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("PushJSONToRemote: %s", err.Error())
+	}
+	res.Body.Close()
+
+	if status != http.StatusOK {
+		t.Errorf("status = %d, want 200", status)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+}
+
+func TestTools_PushJSONToRemote_GivesUpAfterMaxAttempts(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var tools Tools
+
+	_, _, err := tools.PushJSONToRemote(context.Background(), server.URL, map[string]string{"a": "b"}, RemoteRequestOptions{
+		Retry: RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+	})
+	if !errors.Is(err, ErrRemoteRequestFailed) {
+		t.Fatalf("expected ErrRemoteRequestFailed, got %v", err)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+}
+
+func TestTools_PushJSONToRemote_DoesNotRetryOn4xx(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	var tools Tools
+
+	_, status, _ := tools.PushJSONToRemote(context.Background(), server.URL, map[string]string{"a": "b"}, RemoteRequestOptions{
+		Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+	})
+	if status != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", status)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("attempts = %d, want 1 (no retry on 4xx)", got)
+	}
+}
+
+func TestTools_PushJSONToRemote_HonorsRetryAfterHeader(t *testing.T) {
+	var attempts int32
+	var firstAttemptAt time.Time
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			firstAttemptAt = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var tools Tools
+
+	res, _, err := tools.PushJSONToRemote(context.Background(), server.URL, map[string]string{"a": "b"}, RemoteRequestOptions{
+		Retry: RetryPolicy{MaxAttempts: 2},
+	})
+	if err != nil {
+		t.Fatalf("PushJSONToRemote: %s", err.Error())
+	}
+	res.Body.Close()
+
+	if elapsed := time.Since(firstAttemptAt); elapsed < 900*time.Millisecond {
+		t.Errorf("retry happened after %s, want it to wait out the Retry-After header", elapsed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "empty", value: "", want: 0},
+		{name: "seconds", value: "5", want: 5 * time.Second},
+		{name: "unparseable", value: "not-a-date", want: 0},
+		{name: "past HTTP date", value: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), want: 0},
+	}
+
+	for _, e := range tests {
+		t.Run(e.name, func(t *testing.T) {
+			if got := parseRetryAfter(e.value); got != e.want {
+				t.Errorf("parseRetryAfter(%q) = %s, want %s", e.value, got, e.want)
+			}
+		})
+	}
+}
+
+func TestShouldRetryStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusBadRequest, false},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusServiceUnavailable, true},
+	}
+
+	for _, e := range tests {
+		if got := shouldRetryStatus(e.status); got != e.want {
+			t.Errorf("shouldRetryStatus(%d) = %v, want %v", e.status, got, e.want)
+		}
+	}
+}
+
+func TestBackoffDelay_GrowsAndCapsAtMaxDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 40 * time.Millisecond}
+
+	if d := backoffDelay(policy, 1); d > policy.MaxDelay {
+		t.Errorf("attempt 1 delay %s exceeds MaxDelay %s", d, policy.MaxDelay)
+	}
+
+	// Attempt 1 waits 5-10ms and attempt 3 waits 20-40ms, so growth holds despite jitter.
+	if d1, d3 := backoffDelay(policy, 1), backoffDelay(policy, 3); d3 <= d1 {
+		t.Errorf("attempt 3 delay %s should exceed attempt 1 delay %s", d3, d1)
+	}
+
+	if d := backoffDelay(policy, 10); d > policy.MaxDelay {
+		t.Errorf("attempt 10 delay %s should be capped at MaxDelay %s", d, policy.MaxDelay)
+	}
+}
+
+func TestTools_FetchJSON(t *testing.T) {
+	t.Run("decodes a valid response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"name":"gopher"}`))
+		}))
+		defer server.Close()
+
+		var tools Tools
+		var out struct {
+			Name string `json:"name"`
+		}
+
+		if err := tools.FetchJSON(context.Background(), server.URL, &out); err != nil {
+			t.Fatalf("FetchJSON: %s", err.Error())
+		}
+		if out.Name != "gopher" {
+			t.Errorf("Name = %q, want gopher", out.Name)
+		}
+	})
+
+	t.Run("rejects unknown fields by default", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"name":"gopher","extra":true}`))
+		}))
+		defer server.Close()
+
+		var tools Tools
+		var out struct {
+			Name string `json:"name"`
+		}
+
+		if err := tools.FetchJSON(context.Background(), server.URL, &out); err == nil {
+			t.Fatal("expected an error for an unknown field")
+		}
+	})
+
+	t.Run("allows unknown fields when configured", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"name":"gopher","extra":true}`))
+		}))
+		defer server.Close()
+
+		tools := Tools{AllowUnknownFields: true}
+		var out struct {
+			Name string `json:"name"`
+		}
+
+		if err := tools.FetchJSON(context.Background(), server.URL, &out); err != nil {
+			t.Fatalf("FetchJSON: %s", err.Error())
+		}
+	})
+
+	t.Run("rejects a response over MaxJSONSize", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"name":"gopher-with-a-long-name"}`))
+		}))
+		defer server.Close()
+
+		tools := Tools{MaxJSONSize: 5}
+		var out struct {
+			Name string `json:"name"`
+		}
+
+		if err := tools.FetchJSON(context.Background(), server.URL, &out); err == nil {
+			t.Fatal("expected an error for a response over MaxJSONSize")
+		}
+	})
+
+	t.Run("rejects more than one JSON value", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"name":"gopher"}{"name":"again"}`))
+		}))
+		defer server.Close()
+
+		var tools Tools
+		var out struct {
+			Name string `json:"name"`
+		}
+
+		if err := tools.FetchJSON(context.Background(), server.URL, &out); !errors.Is(err, ErrMultipleJSONValues) {
+			t.Errorf("expected ErrMultipleJSONValues, got %v", err)
+		}
+	})
+
+	t.Run("wraps a non-2xx status as ErrRemoteRequestFailed", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		var tools Tools
+		var out struct{}
+
+		if err := tools.FetchJSON(context.Background(), server.URL, &out); !errors.Is(err, ErrRemoteRequestFailed) {
+			t.Errorf("expected ErrRemoteRequestFailed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,350 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrObjectNotFound is returned by a StorageBackend when the requested key does not exist.
+var ErrObjectNotFound = errors.New("object not found")
+
+// ErrInvalidKey is returned by a StorageBackend when key contains a path traversal
+// segment (e.g. "..") that would otherwise let an upload escape its backend's storage
+// root.
+var ErrInvalidKey = errors.New("object key escapes the storage root")
+
+// Metadata is a set of free-form key/value pairs carried alongside a stored object.
+type Metadata map[string]string
+
+// StoredObject describes an object that has been written to a StorageBackend.
+type StoredObject struct {
+	Key      string
+	Size     int64
+	Metadata Metadata
+	ModTime  time.Time
+}
+
+// StorageBackend is the interface UploadFiles and DownloadStaticFile use to persist and
+// retrieve uploaded files. Implementations only need to be safe for concurrent use; they
+// are not expected to be transactional.
+type StorageBackend interface {
+	// Put writes the content of r to key, recording meta alongside it, and returns the
+	// resulting StoredObject.
+	Put(ctx context.Context, key string, r io.Reader, meta Metadata) (StoredObject, error)
+
+	// Get returns a reader for the object stored at key, along with its StoredObject.
+	// Callers must close the returned reader. It returns ErrObjectNotFound if key does
+	// not exist.
+	Get(ctx context.Context, key string) (io.ReadCloser, StoredObject, error)
+
+	// Stat returns metadata about the object stored at key without reading its content.
+	// It returns ErrObjectNotFound if key does not exist.
+	Stat(ctx context.Context, key string) (StoredObject, error)
+
+	// Delete removes the object stored at key. It returns ErrObjectNotFound if key does
+	// not exist.
+	Delete(ctx context.Context, key string) error
+
+	// List returns the keys of every object currently stored. It is used by
+	// StartExpiryReaper to find expired uploads.
+	List(ctx context.Context) ([]string, error)
+}
+
+// Appender is an optional capability a StorageBackend can implement to append to an
+// existing object without reading and rewriting everything already stored under key.
+// tusHandler uses it when available so that a resumable upload's Nth PATCH costs O(chunk
+// size) rather than O(bytes uploaded so far).
+type Appender interface {
+	// Append writes the content of r onto the end of the object stored at key, creating
+	// key first if it does not already exist.
+	Append(ctx context.Context, key string, r io.Reader) error
+}
+
+// LocalFSBackend is a StorageBackend that stores objects as files under Root on the local
+// filesystem. It is the default backend, matching the toolkit's original behavior.
+type LocalFSBackend struct {
+	Root string
+}
+
+// path resolves key to an absolute file path under b.Root, rejecting any key (via ".."
+// segments, an absolute path, etc.) that would resolve outside of it.
+func (b *LocalFSBackend) path(key string) (string, error) {
+	root, err := filepath.Abs(b.Root)
+	if err != nil {
+		return "", err
+	}
+
+	dest, err := filepath.Abs(filepath.Join(root, filepath.FromSlash(key)))
+	if err != nil {
+		return "", err
+	}
+
+	if dest != root && !strings.HasPrefix(dest, root+string(filepath.Separator)) {
+		return "", ErrInvalidKey
+	}
+
+	return dest, nil
+}
+
+// Put implements StorageBackend.
+func (b *LocalFSBackend) Put(_ context.Context, key string, r io.Reader, _ Metadata) (StoredObject, error) {
+	dest, err := b.path(key)
+	if err != nil {
+		return StoredObject{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return StoredObject{}, err
+	}
+
+	outfile, err := os.Create(dest)
+	if err != nil {
+		return StoredObject{}, err
+	}
+	defer outfile.Close()
+
+	size, err := io.Copy(outfile, r)
+	if err != nil {
+		return StoredObject{}, err
+	}
+
+	return StoredObject{Key: key, Size: size, ModTime: time.Now()}, nil
+}
+
+// Append implements Appender.
+func (b *LocalFSBackend) Append(_ context.Context, key string, r io.Reader) error {
+	dest, err := b.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	outfile, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer outfile.Close()
+
+	_, err = io.Copy(outfile, r)
+
+	return err
+}
+
+// Get implements StorageBackend.
+func (b *LocalFSBackend) Get(_ context.Context, key string) (io.ReadCloser, StoredObject, error) {
+	dest, err := b.path(key)
+	if err != nil {
+		return nil, StoredObject{}, err
+	}
+
+	f, err := os.Open(dest)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, StoredObject{}, ErrObjectNotFound
+	} else if err != nil {
+		return nil, StoredObject{}, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, StoredObject{}, err
+	}
+
+	return f, StoredObject{Key: key, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// Stat implements StorageBackend.
+func (b *LocalFSBackend) Stat(_ context.Context, key string) (StoredObject, error) {
+	dest, err := b.path(key)
+	if err != nil {
+		return StoredObject{}, err
+	}
+
+	info, err := os.Stat(dest)
+	if errors.Is(err, os.ErrNotExist) {
+		return StoredObject{}, ErrObjectNotFound
+	} else if err != nil {
+		return StoredObject{}, err
+	}
+
+	return StoredObject{Key: key, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// Delete implements StorageBackend.
+func (b *LocalFSBackend) Delete(_ context.Context, key string) error {
+	dest, err := b.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(dest)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrObjectNotFound
+	}
+
+	return err
+}
+
+// List implements StorageBackend.
+func (b *LocalFSBackend) List(_ context.Context) ([]string, error) {
+	var keys []string
+
+	err := filepath.Walk(b.Root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(b.Root, p)
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, filepath.ToSlash(rel))
+
+		return nil
+	})
+
+	return keys, err
+}
+
+// memoryObject is a single entry held by MemoryBackend.
+type memoryObject struct {
+	data    []byte
+	meta    Metadata
+	modTime time.Time
+}
+
+// MemoryBackend is a StorageBackend that keeps objects in memory. It is intended for use
+// in tests, where exercising a real filesystem or remote object store is unnecessary.
+type MemoryBackend struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+// Put implements StorageBackend.
+func (b *MemoryBackend) Put(_ context.Context, key string, r io.Reader, meta Metadata) (StoredObject, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return StoredObject{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.objects == nil {
+		b.objects = make(map[string]memoryObject)
+	}
+
+	now := time.Now()
+	b.objects[key] = memoryObject{data: data, meta: meta, modTime: now}
+
+	return StoredObject{Key: key, Size: int64(len(data)), Metadata: meta, ModTime: now}, nil
+}
+
+// Append implements Appender.
+func (b *MemoryBackend) Append(_ context.Context, key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.objects == nil {
+		b.objects = make(map[string]memoryObject)
+	}
+
+	obj := b.objects[key]
+	obj.data = append(obj.data, data...)
+	obj.modTime = time.Now()
+	b.objects[key] = obj
+
+	return nil
+}
+
+// Get implements StorageBackend.
+func (b *MemoryBackend) Get(_ context.Context, key string) (io.ReadCloser, StoredObject, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	obj, ok := b.objects[key]
+	if !ok {
+		return nil, StoredObject{}, ErrObjectNotFound
+	}
+
+	return io.NopCloser(bytes.NewReader(obj.data)), StoredObject{
+		Key:      key,
+		Size:     int64(len(obj.data)),
+		Metadata: obj.meta,
+		ModTime:  obj.modTime,
+	}, nil
+}
+
+// Stat implements StorageBackend.
+func (b *MemoryBackend) Stat(_ context.Context, key string) (StoredObject, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	obj, ok := b.objects[key]
+	if !ok {
+		return StoredObject{}, ErrObjectNotFound
+	}
+
+	return StoredObject{Key: key, Size: int64(len(obj.data)), Metadata: obj.meta, ModTime: obj.modTime}, nil
+}
+
+// Delete implements StorageBackend.
+func (b *MemoryBackend) Delete(_ context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.objects[key]; !ok {
+		return ErrObjectNotFound
+	}
+
+	delete(b.objects, key)
+
+	return nil
+}
+
+// List implements StorageBackend.
+func (b *MemoryBackend) List(_ context.Context) ([]string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	keys := make([]string, 0, len(b.objects))
+	for k := range b.objects {
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+// storageBackend returns the backend uploads should be routed through. If t.Backend is
+// unset, a LocalFSBackend rooted at root is used so the default behavior matches the
+// toolkit's original directory-based uploads.
+func (t *Tools) storageBackend(root string) StorageBackend {
+	if t.Backend != nil {
+		return t.Backend
+	}
+
+	return &LocalFSBackend{Root: root}
+}
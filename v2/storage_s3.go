@@ -0,0 +1,156 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Backend is a StorageBackend that stores objects in an S3 (or S3-compatible) bucket,
+// optionally beneath Prefix.
+type S3Backend struct {
+	Client *s3.Client
+	Bucket string
+	Prefix string
+}
+
+func (b *S3Backend) key(key string) string {
+	if b.Prefix == "" {
+		return key
+	}
+
+	return b.Prefix + "/" + key
+}
+
+// Put implements StorageBackend. It streams r to S3 via the managed uploader, so large
+// uploads are sent in multipart chunks rather than buffered in memory.
+func (b *S3Backend) Put(ctx context.Context, key string, r io.Reader, meta Metadata) (StoredObject, error) {
+	uploader := manager.NewUploader(b.Client)
+
+	countingReader := &countingReader{r: r}
+
+	_, err := uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:   aws.String(b.Bucket),
+		Key:      aws.String(b.key(key)),
+		Body:     countingReader,
+		Metadata: meta,
+	})
+	if err != nil {
+		return StoredObject{}, err
+	}
+
+	return StoredObject{Key: key, Size: countingReader.n, Metadata: meta}, nil
+}
+
+// Get implements StorageBackend.
+func (b *S3Backend) Get(ctx context.Context, key string) (io.ReadCloser, StoredObject, error) {
+	out, err := b.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.Bucket),
+		Key:    aws.String(b.key(key)),
+	})
+	if isS3NotFound(err) {
+		return nil, StoredObject{}, ErrObjectNotFound
+	} else if err != nil {
+		return nil, StoredObject{}, err
+	}
+
+	var size int64
+	if out.ContentLength != nil {
+		size = *out.ContentLength
+	}
+
+	return out.Body, StoredObject{Key: key, Size: size, Metadata: out.Metadata}, nil
+}
+
+// Stat implements StorageBackend.
+func (b *S3Backend) Stat(ctx context.Context, key string) (StoredObject, error) {
+	out, err := b.Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(b.Bucket),
+		Key:    aws.String(b.key(key)),
+	})
+	if isS3NotFound(err) {
+		return StoredObject{}, ErrObjectNotFound
+	} else if err != nil {
+		return StoredObject{}, err
+	}
+
+	var size int64
+	if out.ContentLength != nil {
+		size = *out.ContentLength
+	}
+
+	return StoredObject{Key: key, Size: size, Metadata: out.Metadata}, nil
+}
+
+// Delete implements StorageBackend. S3 itself does not report a missing key on delete,
+// so the key is checked with Stat first.
+func (b *S3Backend) Delete(ctx context.Context, key string) error {
+	if _, err := b.Stat(ctx, key); err != nil {
+		return err
+	}
+
+	_, err := b.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(b.Bucket),
+		Key:    aws.String(b.key(key)),
+	})
+
+	return err
+}
+
+// List implements StorageBackend.
+func (b *S3Backend) List(ctx context.Context) ([]string, error) {
+	var keys []string
+
+	paginator := s3.NewListObjectsV2Paginator(b.Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.Bucket),
+		Prefix: aws.String(b.key("")),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			if b.Prefix != "" {
+				key = strings.TrimPrefix(strings.TrimPrefix(key, b.Prefix), "/")
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func isS3NotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+
+	return errors.As(err, &noSuchKey) || errors.As(err, &notFound)
+}
+
+// countingReader wraps an io.Reader and tracks the number of bytes read, since the S3
+// manager.Uploader does not report the final object size back to the caller.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+
+	return n, err
+}
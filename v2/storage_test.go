@@ -0,0 +1,167 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var storageBackendTests = []struct {
+	name    string
+	backend func(t *testing.T) StorageBackend
+}{
+	{name: "memory", backend: func(_ *testing.T) StorageBackend {
+		return &MemoryBackend{}
+	}},
+	{name: "local fs", backend: func(t *testing.T) StorageBackend {
+		return &LocalFSBackend{Root: t.TempDir()}
+	}},
+}
+
+func TestStorageBackend_PutGetStatDeleteList(t *testing.T) {
+	for _, e := range storageBackendTests {
+		t.Run(e.name, func(t *testing.T) {
+			backend := e.backend(t)
+			ctx := context.Background()
+
+			obj, err := backend.Put(ctx, "a/b.txt", bytes.NewReader([]byte("hello")), nil)
+			if err != nil {
+				t.Fatalf("Put: %s", err.Error())
+			}
+			if obj.Size != 5 {
+				t.Errorf("wrong size returned by Put, got %d", obj.Size)
+			}
+
+			stat, err := backend.Stat(ctx, "a/b.txt")
+			if err != nil {
+				t.Fatalf("Stat: %s", err.Error())
+			}
+			if stat.Size != 5 {
+				t.Errorf("wrong size returned by Stat, got %d", stat.Size)
+			}
+
+			rc, rObj, err := backend.Get(ctx, "a/b.txt")
+			if err != nil {
+				t.Fatalf("Get: %s", err.Error())
+			}
+			data, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				t.Fatalf("reading Get result: %s", err.Error())
+			}
+			if string(data) != "hello" {
+				t.Errorf("wrong content returned by Get, got %q", string(data))
+			}
+			if rObj.Size != 5 {
+				t.Errorf("wrong size on Get's StoredObject, got %d", rObj.Size)
+			}
+
+			keys, err := backend.List(ctx)
+			if err != nil {
+				t.Fatalf("List: %s", err.Error())
+			}
+			if len(keys) != 1 || keys[0] != "a/b.txt" {
+				t.Errorf("wrong keys returned by List, got %v", keys)
+			}
+
+			if err := backend.Delete(ctx, "a/b.txt"); err != nil {
+				t.Fatalf("Delete: %s", err.Error())
+			}
+
+			if _, err := backend.Stat(ctx, "a/b.txt"); !errors.Is(err, ErrObjectNotFound) {
+				t.Errorf("expected ErrObjectNotFound after Delete, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStorageBackend_GetMissingReturnsErrObjectNotFound(t *testing.T) {
+	for _, e := range storageBackendTests {
+		t.Run(e.name, func(t *testing.T) {
+			backend := e.backend(t)
+
+			if _, _, err := backend.Get(context.Background(), "does-not-exist"); !errors.Is(err, ErrObjectNotFound) {
+				t.Errorf("expected ErrObjectNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLocalFSBackend_PutCreatesParentDirs(t *testing.T) {
+	root := t.TempDir()
+	backend := &LocalFSBackend{Root: root}
+
+	if _, err := backend.Put(context.Background(), "nested/dir/file.txt", bytes.NewReader([]byte("x")), nil); err != nil {
+		t.Fatalf("Put: %s", err.Error())
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "nested", "dir", "file.txt")); err != nil {
+		t.Errorf("expected file to exist on disk: %s", err.Error())
+	}
+}
+
+func TestLocalFSBackend_RejectsPathTraversal(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("hi"), 0644); err != nil {
+		t.Fatalf("setting up outside file: %s", err.Error())
+	}
+
+	root := filepath.Join(outside, "uploads")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatalf("setting up root: %s", err.Error())
+	}
+
+	backend := &LocalFSBackend{Root: root}
+	ctx := context.Background()
+
+	traversalKeys := []string{
+		"../secret",
+		"../../etc/passwd",
+		"a/../../secret",
+	}
+
+	for _, key := range traversalKeys {
+		if _, err := backend.Put(ctx, key, bytes.NewReader([]byte("pwned")), nil); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q): expected ErrInvalidKey, got %v", key, err)
+		}
+		if _, _, err := backend.Get(ctx, key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Get(%q): expected ErrInvalidKey, got %v", key, err)
+		}
+		if _, err := backend.Stat(ctx, key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Stat(%q): expected ErrInvalidKey, got %v", key, err)
+		}
+		if err := backend.Delete(ctx, key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Delete(%q): expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(outside, "secret")); err != nil || string(data) != "hi" {
+		t.Error("expected the file outside Root to be untouched")
+	}
+}
+
+func TestTools_storageBackend(t *testing.T) {
+	var testTools Tools
+
+	root := t.TempDir()
+	backend := testTools.storageBackend(root)
+
+	lfs, ok := backend.(*LocalFSBackend)
+	if !ok {
+		t.Fatalf("expected *LocalFSBackend when Tools.Backend is unset, got %T", backend)
+	}
+	if lfs.Root != root {
+		t.Errorf("wrong root, got %s", lfs.Root)
+	}
+
+	mem := &MemoryBackend{}
+	testTools.Backend = mem
+
+	if got := testTools.storageBackend(root); got != mem {
+		t.Errorf("expected Tools.Backend to take priority over root, got %T", got)
+	}
+}
@@ -2,26 +2,49 @@ package toolkit
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
 )
 
 const randomStringSource = "abcdefghijklmnopqrstuwxyzABCDEFGHIJKLMNOPQRSTUWXYZ0123456789_+@"
 
+// mimeSniffLimit is how many leading bytes of an upload are buffered for MIME
+// detection, matching mimetype's own default read limit.
+const mimeSniffLimit = 3072
+
 // Tools is the type to instantiate this module.
 type Tools struct {
 	MaxFileSize        int
 	AllowedFileTypes   []string
 	MaxJSONSize        int
 	AllowUnknownFields bool
+
+	// Backend is where UploadFiles and DownloadStaticFile persist and retrieve files.
+	// If nil, a LocalFSBackend rooted at the uploadDir passed to UploadFiles is used,
+	// matching the toolkit's original behavior.
+	Backend StorageBackend
+
+	// MaxExpiry, if non-zero, caps the Expiry a caller may request from
+	// UploadFilesWithOptions. Requests above it fail with ErrExpiryTooLong.
+	MaxExpiry time.Duration
+
+	// ImagePipeline, if set, is run over every uploaded file whose sniffed MIME type is
+	// image/jpeg or image/png (other image types are stored unprocessed), producing the processed main file plus whatever variants (thumbnails,
+	// etc.) its stages generate.
+	ImagePipeline *ImagePipeline
 }
 
 // RandomString returnes random string of n characters from randomStringSource
@@ -42,6 +65,20 @@ type UploadedFile struct {
 	NewFileName      string
 	OriginalFileName string
 	FileSize         int64
+
+	// DeleteKey is the plaintext delete key for this upload. It is only populated when
+	// the file was uploaded via UploadFilesWithOptions; only its bcrypt hash is stored
+	// alongside the file, so the caller must hand this value back to the uploader since
+	// the toolkit itself cannot recover it later.
+	DeleteKey string
+
+	// ExpiresAt is the time at which this upload becomes eligible for removal by
+	// StartExpiryReaper. It is the zero time if the upload never expires.
+	ExpiresAt time.Time
+
+	// Variants holds any additional files an ImagePipeline generated from this upload
+	// (e.g. thumbnails), keyed by the suffix passed to Thumbnail.
+	Variants map[string]UploadedFile
 }
 
 // UploadOneFile is method that allows to upload just one file
@@ -60,100 +97,187 @@ func (t *Tools) UploadOneFile(r *http.Request, uploadDir string, rename ...bool)
 }
 
 // UploadFiles is a method that uploads one or more files to specified uploadDir. It will randomize uploaded file name unless rename is set to false
+//
+// Files are streamed directly to the backend via a multipart.Reader rather than being
+// buffered by ParseMultipartForm, so memory use stays flat regardless of file size. Each
+// file is capped independently at MaxFileSize; a file that exceeds it returns
+// ErrFileTooLarge rather than failing the whole request up front.
 func (t *Tools) UploadFiles(r *http.Request, uploadDir string, rename ...bool) ([]*UploadedFile, error) {
 	renameFile := true
 	if len(rename) > 0 {
 		renameFile = rename[0]
 	}
 
-	var uploadedFiles []*UploadedFile
-
 	if t.MaxFileSize == 0 {
 		t.MaxFileSize = 1024 * 1024 * 1024
 	}
 
-	err := t.CreateDirIfNotExist(uploadDir)
+	backend := t.storageBackend(uploadDir)
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	var uploadedFiles []*UploadedFile
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return uploadedFiles, err
+		}
+
+		if part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		uploadedFile, err := t.uploadPart(r.Context(), backend, part, renameFile)
+		part.Close()
+		if err != nil {
+			return uploadedFiles, err
+		}
+
+		uploadedFiles = append(uploadedFiles, uploadedFile)
+	}
+
+	return uploadedFiles, nil
+}
+
+// uploadPart sniffs the MIME type of a single multipart part, enforces AllowedFileTypes
+// and MaxFileSize, and streams it to backend.
+func (t *Tools) uploadPart(ctx context.Context, backend StorageBackend, part *multipart.Part, renameFile bool) (*UploadedFile, error) {
+	fileType, uploadedFile, stream, err := t.sniffPart(part, renameFile)
+	if err != nil {
+		return nil, err
+	}
+
+	limited := io.LimitReader(stream, int64(t.MaxFileSize)+1)
+
+	if t.ImagePipeline != nil && supportsImageType(fileType.String()) {
+		return t.uploadImagePart(ctx, backend, uploadedFile, limited)
+	}
+
+	return t.uploadStreamPart(ctx, backend, uploadedFile, limited)
+}
+
+// sniffPart reads and MIME-sniffs the head of part, enforces AllowedFileTypes, and
+// returns the detected type, an UploadedFile with its New/OriginalFileName already set,
+// and a reader that replays the whole part (sniffed head included) for the caller to
+// stream onward. Both UploadFiles and UploadFilesWithOptions share this so the type
+// check and naming can't drift between the two entry points.
+func (t *Tools) sniffPart(part *multipart.Part, renameFile bool) (*mimetype.MIME, UploadedFile, io.Reader, error) {
+	head := make([]byte, mimeSniffLimit)
+	n, err := io.ReadFull(part, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, UploadedFile{}, nil, err
+	}
+	head = head[:n]
+
+	fileType := mimetype.Detect(head)
+
+	if !t.fileTypeAllowed(fileType) {
+		return nil, UploadedFile{}, nil, ErrDisallowedType
+	}
+
+	var uploadedFile UploadedFile
+	if renameFile {
+		uploadedFile.NewFileName = fmt.Sprintf("%s%s", t.RandomString(25), filepath.Ext(part.FileName()))
+	} else {
+		uploadedFile.NewFileName = part.FileName()
+	}
+	uploadedFile.OriginalFileName = part.FileName()
+
+	return fileType, uploadedFile, io.MultiReader(bytes.NewReader(head), part), nil
+}
+
+// fileTypeAllowed reports whether fileType passes t.AllowedFileTypes. An empty
+// AllowedFileTypes allows everything.
+func (t *Tools) fileTypeAllowed(fileType *mimetype.MIME) bool {
+	if len(t.AllowedFileTypes) == 0 {
+		return true
+	}
+
+	for _, x := range t.AllowedFileTypes {
+		if strings.EqualFold(fileType.String(), x) || fileType.Is(x) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// uploadStreamPart writes limited (already capped at MaxFileSize+1) to backend under
+// uploadedFile.NewFileName, rejecting it with ErrFileTooLarge if it hit that cap.
+func (t *Tools) uploadStreamPart(ctx context.Context, backend StorageBackend, uploadedFile UploadedFile, limited io.Reader) (*UploadedFile, error) {
+	obj, err := backend.Put(ctx, uploadedFile.NewFileName, limited, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if obj.Size > int64(t.MaxFileSize) {
+		_ = backend.Delete(ctx, uploadedFile.NewFileName)
+		return nil, ErrFileTooLarge
+	}
+
+	uploadedFile.FileSize = obj.Size
+
+	return &uploadedFile, nil
+}
+
+// uploadImagePart runs t.ImagePipeline over a fully-buffered image upload, then writes
+// the processed main image and any generated variants to backend. Buffering the whole
+// file is unavoidable here: the pipeline's decompression-bomb guard needs the declared
+// image dimensions before it decodes anything, and decoding itself needs random access
+// to the data.
+func (t *Tools) uploadImagePart(ctx context.Context, backend StorageBackend, uploadedFile UploadedFile, r io.Reader) (*UploadedFile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > int64(t.MaxFileSize) {
+		return nil, ErrFileTooLarge
+	}
+
+	main, variants, err := t.ImagePipeline.run(data)
 	if err != nil {
 		return nil, err
 	}
 
-	err = r.ParseMultipartForm(int64(t.MaxFileSize))
+	uploadedFile.NewFileName = strings.TrimSuffix(uploadedFile.NewFileName, filepath.Ext(uploadedFile.NewFileName)) + main.ext
+
+	obj, err := backend.Put(ctx, uploadedFile.NewFileName, bytes.NewReader(main.data), nil)
 	if err != nil {
-		return nil, errors.New("uploaded file is too big")
-	}
-
-	for _, fHEaders := range r.MultipartForm.File {
-		for _, hdr := range fHEaders {
-			uploadedFiles, err = func(uploadedFiles []*UploadedFile) ([]*UploadedFile, error) {
-				var uploadedFile UploadedFile
-				infile, err := hdr.Open()
-				if err != nil {
-					return nil, err
-				}
-				defer infile.Close()
-
-				buff := make([]byte, 512)
-				_, err = infile.Read(buff)
-				if err != nil {
-					return nil, err
-				}
-
-				allowed := false
-				fileType := http.DetectContentType(buff)
-
-				if len(t.AllowedFileTypes) > 0 {
-					for _, x := range t.AllowedFileTypes {
-						if strings.EqualFold(fileType, x) {
-							allowed = true
-						}
-					}
-				} else {
-					allowed = true
-				}
-
-				if !allowed {
-					return nil, errors.New("uploaded file type is not permitted")
-				}
-
-				_, err = infile.Seek(0, 0)
-				if err != nil {
-					return nil, err
-				}
-
-				if renameFile {
-					uploadedFile.NewFileName = fmt.Sprintf("%s%s", t.RandomString(25), filepath.Ext(hdr.Filename))
-				} else {
-					uploadedFile.NewFileName = hdr.Filename
-				}
-
-				uploadedFile.OriginalFileName = hdr.Filename
-
-				var outfile *os.File
-				defer outfile.Close()
-
-				if outfile, err := os.Create(filepath.Join(uploadDir, uploadedFile.NewFileName)); err != nil {
-					return nil, err
-				} else {
-					fileSize, err := io.Copy(outfile, infile)
-					if err != nil {
-						return nil, err
-					}
-					uploadedFile.FileSize = fileSize
-				}
-
-				uploadedFiles = append(uploadedFiles, &uploadedFile)
-
-				return uploadedFiles, nil
-
-			}(uploadedFiles)
+		return nil, err
+	}
+	uploadedFile.FileSize = obj.Size
+
+	if len(variants) > 0 {
+		uploadedFile.Variants = make(map[string]UploadedFile, len(variants))
 
+		base := strings.TrimSuffix(uploadedFile.NewFileName, filepath.Ext(uploadedFile.NewFileName))
+
+		for suffix, v := range variants {
+			key := fmt.Sprintf("%s.%s%s", base, suffix, v.ext)
+
+			vObj, err := backend.Put(ctx, key, bytes.NewReader(v.data), nil)
 			if err != nil {
-				return uploadedFiles, err
+				return nil, err
+			}
+
+			uploadedFile.Variants[suffix] = UploadedFile{
+				NewFileName:      key,
+				OriginalFileName: uploadedFile.OriginalFileName,
+				FileSize:         vObj.Size,
 			}
 		}
 	}
 
-	return uploadedFiles, nil
+	return &uploadedFile, nil
 }
 
 // CreateDirIfNotExist creates directory with required parents, if does not exist
@@ -185,11 +309,26 @@ func (t *Tools) Slugify(s string) (string, error) {
 	return slug, nil
 }
 
-// DownloadStaticFile method downloads a file
-func (t *Tools) DownloadStaticFile(w http.ResponseWriter, r *http.Request, pathName, displayName string) {
+// DownloadStaticFile method downloads a file, routing the read through the configured
+// StorageBackend so it works the same whether uploadDir is a local directory or backed
+// by something remote.
+func (t *Tools) DownloadStaticFile(w http.ResponseWriter, r *http.Request, uploadDir, fileName, displayName string) {
+	backend := t.storageBackend(uploadDir)
+
+	rc, obj, err := backend.Get(r.Context(), fileName)
+	if errors.Is(err, ErrObjectNotFound) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rc.Close()
+
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", displayName))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", obj.Size))
 
-	http.ServeFile(w, r, pathName)
+	_, _ = io.Copy(w, rc)
 }
 
 // JSONResponse represents generic json response payload
@@ -223,23 +362,23 @@ func (t *Tools) ReadJSON(w http.ResponseWriter, r *http.Request, data any) error
 
 		switch {
 		case errors.As(err, &syntaxError):
-			return fmt.Errorf("body contains badly formed JSON at character %d", syntaxError.Offset)
+			return jsonError(http.StatusBadRequest, "bad_json", ErrBadJSON, "body contains badly formed JSON at character %d", syntaxError.Offset)
 		case errors.Is(err, io.ErrUnexpectedEOF):
-			return errors.New("body contains badly formed JSON")
+			return jsonError(http.StatusBadRequest, "bad_json", ErrBadJSON, "body contains badly formed JSON")
 		case errors.As(err, &unmarshalTypeError):
 			if unmarshalTypeError.Field != "" {
-				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+				return jsonError(http.StatusBadRequest, "wrong_json_type", ErrWrongJSONType, "body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
 			}
-			return fmt.Errorf("body contains incorrect JSON type at characted %d", unmarshalTypeError.Offset)
+			return jsonError(http.StatusBadRequest, "wrong_json_type", ErrWrongJSONType, "body contains incorrect JSON type at characted %d", unmarshalTypeError.Offset)
 		case errors.Is(err, io.EOF):
-			return errors.New("body must not be empty")
+			return jsonError(http.StatusBadRequest, "empty_body", ErrEmptyBody, "body must not be empty")
 		case strings.HasPrefix(err.Error(), "json: unknown field"):
 			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field")
-			return fmt.Errorf("body contains unknown key %s", fieldName)
+			return jsonError(http.StatusBadRequest, "unknown_field", ErrUnknownField, "body contains unknown key %s", fieldName)
 		case err.Error() == "http: request body too large":
-			return fmt.Errorf("body must not be larged than %d bytes", maxBytes)
+			return jsonError(http.StatusRequestEntityTooLarge, "body_too_large", ErrBodyTooLarge, "body must not be larged than %d bytes", maxBytes)
 		case errors.As(err, &invalidUnmarshalError):
-			return fmt.Errorf("error unmarshaling JSON %s", err.Error())
+			return jsonError(http.StatusBadRequest, "bad_json", ErrBadJSON, "error unmarshaling JSON %s", err.Error())
 		default:
 			return err
 		}
@@ -247,7 +386,7 @@ func (t *Tools) ReadJSON(w http.ResponseWriter, r *http.Request, data any) error
 
 	err = dec.Decode(&struct{}{})
 	if err != io.EOF {
-		return errors.New("body must contain only one JSON value")
+		return jsonError(http.StatusBadRequest, "multiple_json_values", ErrMultipleJSONValues, "body must contain only one JSON value")
 	}
 
 	return nil
@@ -292,31 +431,3 @@ func (t *Tools) ErrorJSON(w http.ResponseWriter, err error, status ...int) error
 	return t.WriteJSON(w, statusCode, payload)
 }
 
-// PushJSONToRemote posts arbitrary data to remote url as JSON and returned response, status code and possibly error
-func (t *Tools) PushJSONToRemote(uri string, data any, client ...*http.Client) (*http.Response, int, error) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	httpClient := &http.Client{}
-
-	if len(client) > 0 {
-		httpClient = client[0]
-	}
-
-	req, err := http.NewRequest("POST", uri, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, 0, err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	res, err := httpClient.Do(req)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer res.Body.Close()
-
-	return res, res.StatusCode, nil
-}
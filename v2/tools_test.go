@@ -0,0 +1,115 @@
+package toolkit
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// pngBytes renders a tiny solid-color PNG, large enough for mimetype to sniff as
+// image/png without needing any files on disk.
+func pngBytes(t *testing.T) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encoding test PNG: %s", err.Error())
+	}
+
+	return buf.Bytes()
+}
+
+var uploadTests = []struct {
+	name          string
+	allowedTypes  []string
+	maxFileSize   int
+	renameFile    bool
+	errorExpected error
+}{
+	{name: "allowed no rename", allowedTypes: []string{"image/png"}, renameFile: false},
+	{name: "allowed rename", allowedTypes: []string{"image/png"}, renameFile: true},
+	{name: "disallowed type", allowedTypes: []string{"image/jpeg"}, errorExpected: ErrDisallowedType},
+	{name: "too large", maxFileSize: 10, errorExpected: ErrFileTooLarge},
+}
+
+func TestTools_UploadFiles(t *testing.T) {
+	for _, e := range uploadTests {
+		t.Run(e.name, func(t *testing.T) {
+			content := pngBytes(t)
+
+			pr, pw := io.Pipe()
+			writer := multipart.NewWriter(pw)
+			wg := sync.WaitGroup{}
+			wg.Add(1)
+
+			go func() {
+				defer writer.Close()
+				defer wg.Done()
+
+				part, err := writer.CreateFormFile("file", "upload.png")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				if _, err := part.Write(content); err != nil {
+					t.Error(err)
+				}
+			}()
+
+			request := httptest.NewRequest("POST", "/", pr)
+			request.Header.Add("Content-Type", writer.FormDataContentType())
+
+			testTools := Tools{
+				AllowedFileTypes: e.allowedTypes,
+				Backend:          &MemoryBackend{},
+			}
+			if e.maxFileSize > 0 {
+				testTools.MaxFileSize = e.maxFileSize
+			}
+
+			uploadedFiles, err := testTools.UploadFiles(request, "uploads", e.renameFile)
+			wg.Wait()
+
+			if e.errorExpected != nil {
+				if !errors.Is(err, e.errorExpected) {
+					t.Errorf("expected %v, got %v", e.errorExpected, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+
+			if len(uploadedFiles) != 1 {
+				t.Fatalf("expected 1 uploaded file, got %d", len(uploadedFiles))
+			}
+
+			if e.renameFile && uploadedFiles[0].NewFileName == "upload.png" {
+				t.Error("expected file to be renamed")
+			}
+			if !e.renameFile && uploadedFiles[0].NewFileName != "upload.png" {
+				t.Errorf("expected original file name to be kept, got %s", uploadedFiles[0].NewFileName)
+			}
+
+			if _, err := testTools.Backend.Stat(request.Context(), uploadedFiles[0].NewFileName); err != nil {
+				t.Errorf("expected uploaded file to exist on backend: %s", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TusResumableVersion is the tus.io protocol version TusHandler implements.
+const TusResumableVersion = "1.0.0"
+
+// tusStateSuffix is appended to an upload's key to form the key of its tus state sidecar.
+const tusStateSuffix = ".tus.json"
+
+// tusUploadState is the per-upload bookkeeping TusHandler persists to Backend so that
+// partially uploaded files survive a process restart.
+type tusUploadState struct {
+	ID        string            `json:"id"`
+	Length    int64             `json:"length"`
+	Offset    int64             `json:"offset"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+
+	// FinalKey is set once the upload is complete and its content has been deduped into
+	// a content-addressed object; it is where the assembled file actually lives.
+	FinalKey string `json:"final_key,omitempty"`
+}
+
+// tusHandler implements the tus.io resumable upload protocol against a StorageBackend.
+type tusHandler struct {
+	tools   *Tools
+	backend StorageBackend
+}
+
+// TusHandler returns an http.Handler implementing the tus.io resumable upload protocol,
+// storing uploads (and their in-progress state) via the configured Backend, or a
+// LocalFSBackend rooted at uploadDir if none is set. Mount it at a stable path and use
+// that path plus the Location header returned from a creation POST as each upload's URL.
+func (t *Tools) TusHandler(uploadDir string) http.Handler {
+	return &tusHandler{tools: t, backend: t.storageBackend(uploadDir)}
+}
+
+func (h *tusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", TusResumableVersion)
+
+	switch r.Method {
+	case http.MethodPost:
+		h.create(w, r)
+	case http.MethodPatch:
+		h.patch(w, r)
+	case http.MethodHead:
+		h.head(w, r)
+	case http.MethodDelete:
+		h.delete(w, r)
+	case http.MethodOptions:
+		h.options(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *tusHandler) options(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Tus-Version", TusResumableVersion)
+	w.Header().Set("Tus-Extension", "creation")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *tusHandler) create(w http.ResponseWriter, r *http.Request) {
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		http.Error(w, "missing or invalid Upload-Length", http.StatusBadRequest)
+		return
+	}
+
+	if h.tools.MaxFileSize > 0 && length > int64(h.tools.MaxFileSize) {
+		http.Error(w, "Upload-Length exceeds the maximum allowed file size", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	state := tusUploadState{
+		ID:        h.tools.RandomString(32),
+		Length:    length,
+		Metadata:  parseTusMetadata(r.Header.Get("Upload-Metadata")),
+		CreatedAt: time.Now(),
+	}
+
+	if _, err := h.backend.Put(r.Context(), state.ID, bytes.NewReader(nil), nil); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.putState(r.Context(), state); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", state.ID)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *tusHandler) patch(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		http.Error(w, "unsupported Content-Type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	id := path.Base(r.URL.Path)
+
+	state, err := h.getState(r.Context(), id)
+	if err != nil {
+		http.Error(w, "unknown upload", http.StatusNotFound)
+		return
+	}
+
+	if state.FinalKey != "" {
+		// The upload already completed and its raw object was deduped away in finalize.
+		// A client that never saw that response (e.g. the connection dropped) will
+		// retry this exact PATCH; treat it as a no-op instead of failing to read an
+		// object that's deliberately gone. This runs before the offset check because
+		// the retried PATCH still carries the offset from before it completed.
+		w.Header().Set("Upload-Offset", strconv.FormatInt(state.Offset, 10))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset != state.Offset {
+		http.Error(w, "offset mismatch", http.StatusConflict)
+		return
+	}
+
+	chunk := &countingReader{r: io.LimitReader(r.Body, state.Length-state.Offset)}
+
+	if appender, ok := h.backend.(Appender); ok {
+		if err := appender.Append(r.Context(), id, chunk); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		existing, _, err := h.backend.Get(r.Context(), id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		_, err = h.backend.Put(r.Context(), id, io.MultiReader(existing, chunk), nil)
+		existing.Close()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	state.Offset += chunk.n
+
+	if state.Offset >= state.Length {
+		h.finalize(r.Context(), &state)
+	}
+
+	if err := h.putState(r.Context(), state); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(state.Offset, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// finalize hashes the fully assembled upload and dedupes it into a content-addressed
+// object, so that identical uploads share storage.
+func (h *tusHandler) finalize(ctx context.Context, state *tusUploadState) {
+	rc, _, err := h.backend.Get(ctx, state.ID)
+	if err != nil {
+		return
+	}
+
+	hasher := sha256.New()
+	_, err = io.Copy(hasher, rc)
+	rc.Close()
+	if err != nil {
+		return
+	}
+
+	casKey := "cas/" + hex.EncodeToString(hasher.Sum(nil))
+
+	if _, err := h.backend.Stat(ctx, casKey); errors.Is(err, ErrObjectNotFound) {
+		// Re-read the assembled upload rather than holding it fully in memory from the
+		// hashing pass above; a large upload would otherwise double its peak memory use
+		// here for no benefit.
+		src, _, err := h.backend.Get(ctx, state.ID)
+		if err != nil {
+			return
+		}
+
+		_, err = h.backend.Put(ctx, casKey, src, nil)
+		src.Close()
+		if err != nil {
+			return
+		}
+	}
+
+	// The raw object at state.ID has now been superseded by the deduped casKey object;
+	// drop it so repeat uploads of the same content don't keep a redundant copy around.
+	// Readers must resolve completed uploads through FinalKey, not ID.
+	_ = h.backend.Delete(ctx, state.ID)
+
+	state.FinalKey = casKey
+}
+
+func (h *tusHandler) head(w http.ResponseWriter, r *http.Request) {
+	id := path.Base(r.URL.Path)
+
+	state, err := h.getState(r.Context(), id)
+	if err != nil {
+		http.Error(w, "unknown upload", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(state.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(state.Length, 10))
+	w.Header().Set("Cache-Control", "no-store")
+
+	if state.FinalKey != "" {
+		// The raw upload at id has been deduped away; tell the caller where the
+		// finished content actually lives.
+		w.Header().Set("Upload-Final-Key", state.FinalKey)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *tusHandler) delete(w http.ResponseWriter, r *http.Request) {
+	id := path.Base(r.URL.Path)
+
+	_ = h.backend.Delete(r.Context(), id)
+	_ = h.backend.Delete(r.Context(), id+tusStateSuffix)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *tusHandler) putState(ctx context.Context, state tusUploadState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.backend.Put(ctx, state.ID+tusStateSuffix, bytes.NewReader(data), nil)
+
+	return err
+}
+
+func (h *tusHandler) getState(ctx context.Context, id string) (tusUploadState, error) {
+	rc, _, err := h.backend.Get(ctx, id+tusStateSuffix)
+	if err != nil {
+		return tusUploadState{}, err
+	}
+	defer rc.Close()
+
+	var state tusUploadState
+	if err := json.NewDecoder(rc).Decode(&state); err != nil {
+		return tusUploadState{}, err
+	}
+
+	return state, nil
+}
+
+// parseTusMetadata decodes a tus Upload-Metadata header, a comma-separated list of
+// "key base64(value)" pairs, into a plain map.
+func parseTusMetadata(header string) map[string]string {
+	if header == "" {
+		return nil
+	}
+
+	meta := make(map[string]string)
+
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			continue
+		}
+
+		meta[parts[0]] = string(decoded)
+	}
+
+	return meta
+}
@@ -0,0 +1,165 @@
+package toolkit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func tusUpload(t *testing.T, handler http.Handler, content []byte) (location string) {
+	t.Helper()
+
+	createReq := httptest.NewRequest("POST", "/tus", nil)
+	createReq.Header.Set("Upload-Length", strconv.Itoa(len(content)))
+	createRec := httptest.NewRecorder()
+	handler.ServeHTTP(createRec, createReq)
+
+	if createRec.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d", createRec.Code)
+	}
+
+	location = createRec.Header().Get("Location")
+	if location == "" {
+		t.Fatal("create: expected a Location header")
+	}
+
+	patchReq := httptest.NewRequest("PATCH", "/tus/"+location, strings.NewReader(string(content)))
+	patchReq.Header.Set("Content-Type", "application/offset+octet-stream")
+	patchReq.Header.Set("Upload-Offset", "0")
+	patchRec := httptest.NewRecorder()
+	handler.ServeHTTP(patchRec, patchReq)
+
+	if patchRec.Code != http.StatusNoContent {
+		t.Fatalf("patch: expected 204, got %d: %s", patchRec.Code, patchRec.Body.String())
+	}
+
+	return location
+}
+
+func TestTusHandler_UploadDedupesIdenticalContent(t *testing.T) {
+	backend := &MemoryBackend{}
+	tools := &Tools{Backend: backend}
+	handler := tools.TusHandler("uploads")
+
+	content := []byte("identical content uploaded twice")
+	hash := sha256.Sum256(content)
+	casKey := "cas/" + hex.EncodeToString(hash[:])
+
+	firstID := tusUpload(t, handler, content)
+	secondID := tusUpload(t, handler, content)
+
+	if firstID == secondID {
+		t.Fatalf("expected distinct upload IDs, got the same: %s", firstID)
+	}
+
+	if _, err := backend.Stat(context.Background(), casKey); err != nil {
+		t.Fatalf("expected deduped object to exist: %s", err.Error())
+	}
+
+	if _, err := backend.Stat(context.Background(), firstID); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("expected the raw upload object to be removed after finalize, got %v", err)
+	}
+	if _, err := backend.Stat(context.Background(), secondID); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("expected the raw upload object to be removed after finalize, got %v", err)
+	}
+
+	keys, err := backend.List(context.Background())
+	if err != nil {
+		t.Fatalf("List: %s", err.Error())
+	}
+
+	casCount := 0
+	for _, k := range keys {
+		if k == casKey {
+			casCount++
+		}
+	}
+	if casCount != 1 {
+		t.Errorf("expected exactly one deduped object, found %d among %v", casCount, keys)
+	}
+}
+
+func tusPatch(t *testing.T, handler http.Handler, id string, offset int64, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest("PATCH", "/tus/"+id, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestTusHandler_ResumesAcrossMultiplePatches(t *testing.T) {
+	backend := &MemoryBackend{}
+	tools := &Tools{Backend: backend}
+	handler := tools.TusHandler("uploads")
+
+	content := "first half|second half"
+	firstHalf, secondHalf := content[:10], content[10:]
+
+	createReq := httptest.NewRequest("POST", "/tus", nil)
+	createReq.Header.Set("Upload-Length", strconv.Itoa(len(content)))
+	createRec := httptest.NewRecorder()
+	handler.ServeHTTP(createRec, createReq)
+	if createRec.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d", createRec.Code)
+	}
+	id := createRec.Header().Get("Location")
+
+	if rec := tusPatch(t, handler, id, 0, firstHalf); rec.Code != http.StatusNoContent {
+		t.Fatalf("first patch: expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The connection drops before the client sees the response to the completing PATCH;
+	// it retries the identical request.
+	rec := tusPatch(t, handler, id, int64(len(firstHalf)), secondHalf)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("completing patch: expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	retryRec := tusPatch(t, handler, id, int64(len(firstHalf)), secondHalf)
+	if retryRec.Code != http.StatusNoContent {
+		t.Fatalf("retried completing patch: expected 204, got %d: %s", retryRec.Code, retryRec.Body.String())
+	}
+
+	hash := sha256.Sum256([]byte(content))
+	casKey := "cas/" + hex.EncodeToString(hash[:])
+
+	rc, _, err := backend.Get(context.Background(), casKey)
+	if err != nil {
+		t.Fatalf("expected assembled upload to exist at %s: %s", casKey, err.Error())
+	}
+	defer rc.Close()
+
+	data := make([]byte, len(content))
+	if _, err := io.ReadFull(rc, data); err != nil {
+		t.Fatalf("reading assembled upload: %s", err.Error())
+	}
+	if string(data) != content {
+		t.Errorf("wrong assembled content, got %q", string(data))
+	}
+}
+
+func TestTusHandler_CreateRejectsLengthOverMaxFileSize(t *testing.T) {
+	tools := &Tools{Backend: &MemoryBackend{}, MaxFileSize: 10}
+	handler := tools.TusHandler("uploads")
+
+	req := httptest.NewRequest("POST", "/tus", nil)
+	req.Header.Set("Upload-Length", "1000")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", rec.Code)
+	}
+}